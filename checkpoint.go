@@ -0,0 +1,284 @@
+package workflow
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNoCheckpoint is returned by Checkpointer.Load when no state has been
+// saved for a workflow ID yet.
+var ErrNoCheckpoint = errors.New("workflow: no checkpoint found")
+
+// Stateful is an optional interface a Task can implement so its own
+// progress is captured in the WorkflowState a Checkpointer persists.
+type Stateful interface {
+	MarshalState() ([]byte, error)
+	UnmarshalState([]byte) error
+}
+
+// WorkflowState is the snapshot PipelineTask and DAGTask persist through a
+// Checkpointer at each task boundary.
+type WorkflowState struct {
+	WorkflowID string `json:"workflow_id"`
+	// Step is the index of the last completed task, for PipelineTask.
+	Step int `json:"step"`
+	// CompletedNodes is the set of finished node IDs, for DAGTask.
+	CompletedNodes []string `json:"completed_nodes,omitempty"`
+	// TaskState holds the opaque payload of every task completed so far that
+	// implements Stateful, keyed by Task.ID().
+	TaskState map[string][]byte `json:"task_state,omitempty"`
+	// Attempts maps Task.ID() to a commit-attempt count. PipelineTask and
+	// DAGTask never fill it in, so the snapshots they save leave it empty.
+	Attempts map[string]int `json:"attempts,omitempty"`
+}
+
+// Checkpointer persists and restores WorkflowState so a crashed workflow
+// can resume from its last committed step instead of starting over.
+type Checkpointer interface {
+	Save(ctx context.Context, workflowID string, state WorkflowState) error
+	Load(ctx context.Context, workflowID string) (WorkflowState, error)
+	Delete(ctx context.Context, workflowID string) error
+}
+
+// WithCheckpointer enables checkpointing for a PipelineTask or DAGTask.
+func WithCheckpointer(c Checkpointer) Option {
+	return func(o *option) {
+		o.checkpointer = c
+	}
+}
+
+// WithWorkflowID sets the ID a PipelineTask or DAGTask checkpoints under.
+// It defaults to the task's own ID if unset.
+func WithWorkflowID(id string) Option {
+	return func(o *option) {
+		o.workflowID = id
+	}
+}
+
+// stateOf snapshots t if it implements Stateful, returning nil otherwise.
+func stateOf(t Task) map[string][]byte {
+	st, ok := t.(Stateful)
+	if !ok {
+		return nil
+	}
+	b, err := st.MarshalState()
+	if err != nil {
+		return nil
+	}
+	return map[string][]byte{t.ID(): b}
+}
+
+// restoreState feeds t's entry in taskState back into it via UnmarshalState,
+// if t implements Stateful and a payload was saved under its ID. It is a
+// no-op otherwise, including when UnmarshalState itself errors — a task
+// that can't make sense of its saved state just runs as if it had none.
+func restoreState(t Task, taskState map[string][]byte) {
+	st, ok := t.(Stateful)
+	if !ok {
+		return
+	}
+	b, ok := taskState[t.ID()]
+	if !ok {
+		return
+	}
+	_ = st.UnmarshalState(b)
+}
+
+// mergeState returns a new map holding every entry of existing plus entry's,
+// entry's entry winning on key collision; when entry is empty it returns
+// existing itself. It never mutates existing, so a
+// map handed off to a Checkpointer.Save in another goroutine stays a
+// consistent snapshot even as later completions keep accumulating state.
+func mergeState(existing, entry map[string][]byte) map[string][]byte {
+	if len(entry) == 0 {
+		return existing
+	}
+	merged := make(map[string][]byte, len(existing)+len(entry))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	for k, v := range entry {
+		merged[k] = v
+	}
+	return merged
+}
+
+// ResumeTask loads the last checkpoint saved for workflowID, if any, and
+// configures t (a PipelineTask or DAGTask built with WithCheckpointer) to
+// skip the steps or nodes it already completed, continuing to checkpoint
+// under checkpointer/workflowID as it runs the rest.
+func ResumeTask(ctx context.Context, checkpointer Checkpointer, workflowID string, t Task) (Task, error) {
+	state, err := checkpointer.Load(ctx, workflowID)
+	if err != nil && !errors.Is(err, ErrNoCheckpoint) {
+		return nil, err
+	}
+	found := err == nil
+
+	switch rt := t.(type) {
+	case *pipelineTask:
+		rt.checkpointer = checkpointer
+		rt.workflowID = workflowID
+		if found {
+			rt.resumeFrom = state.Step + 1
+			rt.taskState = state.TaskState
+		}
+	case *dagTask:
+		rt.checkpointer = checkpointer
+		rt.workflowID = workflowID
+		if found {
+			rt.skip = make(map[string]bool, len(state.CompletedNodes))
+			for _, id := range state.CompletedNodes {
+				rt.skip[id] = true
+			}
+			rt.taskState = state.TaskState
+		}
+	default:
+		return nil, fmt.Errorf("workflow: %T does not support checkpoint resume", t)
+	}
+	return t, nil
+}
+
+// MemoryCheckpointer is an in-memory Checkpointer, mainly useful for tests
+// and single-process workflows.
+type MemoryCheckpointer struct {
+	mu     sync.Mutex
+	states map[string]WorkflowState
+}
+
+func NewMemoryCheckpointer() *MemoryCheckpointer {
+	return &MemoryCheckpointer{states: make(map[string]WorkflowState)}
+}
+
+func (m *MemoryCheckpointer) Save(_ context.Context, workflowID string, state WorkflowState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[workflowID] = state
+	return nil
+}
+
+func (m *MemoryCheckpointer) Load(_ context.Context, workflowID string) (WorkflowState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.states[workflowID]
+	if !ok {
+		return WorkflowState{}, ErrNoCheckpoint
+	}
+	return state, nil
+}
+
+func (m *MemoryCheckpointer) Delete(_ context.Context, workflowID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.states, workflowID)
+	return nil
+}
+
+// FileCheckpointer persists each workflow's state as a JSON file named
+// "<workflowID>.json" under Dir.
+type FileCheckpointer struct {
+	Dir string
+}
+
+func NewFileCheckpointer(dir string) *FileCheckpointer {
+	return &FileCheckpointer{Dir: dir}
+}
+
+func (f *FileCheckpointer) path(workflowID string) string {
+	return filepath.Join(f.Dir, workflowID+".json")
+}
+
+func (f *FileCheckpointer) Save(_ context.Context, workflowID string, state WorkflowState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(f.path(workflowID), b, 0o644)
+}
+
+func (f *FileCheckpointer) Load(_ context.Context, workflowID string) (WorkflowState, error) {
+	b, err := os.ReadFile(f.path(workflowID))
+	if errors.Is(err, os.ErrNotExist) {
+		return WorkflowState{}, ErrNoCheckpoint
+	}
+	if err != nil {
+		return WorkflowState{}, err
+	}
+	var state WorkflowState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return WorkflowState{}, err
+	}
+	return state, nil
+}
+
+func (f *FileCheckpointer) Delete(_ context.Context, workflowID string) error {
+	err := os.Remove(f.path(workflowID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// SQLCheckpointer persists state as JSON in a SQL table, created on demand
+// by CreateTable.
+type SQLCheckpointer struct {
+	DB    *sql.DB
+	Table string
+}
+
+func NewSQLCheckpointer(db *sql.DB, table string) *SQLCheckpointer {
+	if table == "" {
+		table = "workflow_checkpoints"
+	}
+	return &SQLCheckpointer{DB: db, Table: table}
+}
+
+// CreateTable creates the checkpoint table if it doesn't already exist.
+func (s *SQLCheckpointer) CreateTable(ctx context.Context) error {
+	_, err := s.DB.ExecContext(ctx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (workflow_id VARCHAR(255) PRIMARY KEY, state TEXT NOT NULL)`, s.Table))
+	return err
+}
+
+func (s *SQLCheckpointer) Save(ctx context.Context, workflowID string, state WorkflowState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ExecContext(ctx, fmt.Sprintf(
+		`INSERT INTO %s (workflow_id, state) VALUES ($1, $2)
+		 ON CONFLICT (workflow_id) DO UPDATE SET state = excluded.state`, s.Table),
+		workflowID, string(b))
+	return err
+}
+
+func (s *SQLCheckpointer) Load(ctx context.Context, workflowID string) (WorkflowState, error) {
+	row := s.DB.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT state FROM %s WHERE workflow_id = $1`, s.Table), workflowID)
+	var raw string
+	if err := row.Scan(&raw); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WorkflowState{}, ErrNoCheckpoint
+		}
+		return WorkflowState{}, err
+	}
+	var state WorkflowState
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		return WorkflowState{}, err
+	}
+	return state, nil
+}
+
+func (s *SQLCheckpointer) Delete(ctx context.Context, workflowID string) error {
+	_, err := s.DB.ExecContext(ctx,
+		fmt.Sprintf(`DELETE FROM %s WHERE workflow_id = $1`, s.Table), workflowID)
+	return err
+}
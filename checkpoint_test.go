@@ -0,0 +1,117 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// statefulTask is a Task that also implements Stateful, tracking a simple
+// counter so a test can observe whether ResumeTask fed a saved value back
+// into it.
+type statefulTask struct {
+	id      string
+	counter int
+}
+
+func (s *statefulTask) ID() string   { return s.id }
+func (s *statefulTask) Name() string { return s.id }
+func (s *statefulTask) Commit(context.Context) error {
+	s.counter++
+	return nil
+}
+func (s *statefulTask) Rollback(context.Context) error { return nil }
+func (s *statefulTask) MarshalState() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d", s.counter)), nil
+}
+func (s *statefulTask) UnmarshalState(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "%d", &s.counter)
+	return err
+}
+
+func TestResumeTaskRestoresStatefulPipelineState(t *testing.T) {
+	ckpt := NewMemoryCheckpointer()
+	ctx := context.Background()
+
+	first := &statefulTask{id: "step-0", counter: 41}
+	failing := NewFuncTask(func(context.Context) error { return fmt.Errorf("boom") })
+
+	pipeline := PipelineTask(WithTasks(first, failing), WithCheckpointer(ckpt), WithWorkflowID("wf-1"))
+	if err := pipeline.Commit(ctx); err == nil {
+		t.Fatal("expected the second step to fail")
+	}
+
+	resumed := &statefulTask{id: "step-0", counter: 0}
+	second := NewFuncTask(func(context.Context) error { return nil })
+	fresh := PipelineTask(WithTasks(resumed, second), WithWorkflowID("wf-1"))
+
+	rt, err := ResumeTask(ctx, ckpt, "wf-1", fresh)
+	if err != nil {
+		t.Fatalf("ResumeTask: %v", err)
+	}
+	if err := rt.Commit(ctx); err != nil {
+		t.Fatalf("Commit after resume: %v", err)
+	}
+
+	if resumed.counter != 42 {
+		t.Fatalf("expected the saved TaskState (counter=42, saved after the first attempt's successful Commit) to be fed back via UnmarshalState, got %d", resumed.counter)
+	}
+}
+
+func TestPipelineCheckpointAccumulatesStateAcrossSteps(t *testing.T) {
+	ckpt := NewMemoryCheckpointer()
+	ctx := context.Background()
+
+	a := &statefulTask{id: "a", counter: 1}
+	b := &statefulTask{id: "b", counter: 2}
+	failing := NewFuncTask(func(context.Context) error { return fmt.Errorf("boom") })
+
+	pipeline := PipelineTask(WithTasks(a, b, failing), WithCheckpointer(ckpt), WithWorkflowID("wf-2"))
+	if err := pipeline.Commit(ctx); err == nil {
+		t.Fatal("expected the third step to fail")
+	}
+
+	state, err := ckpt.Load(ctx, "wf-2")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, ok := state.TaskState["a"]; !ok {
+		t.Fatalf("expected step a's state to survive step b's checkpoint Save, got %v", state.TaskState)
+	}
+	if _, ok := state.TaskState["b"]; !ok {
+		t.Fatalf("expected step b's state in the checkpoint, got %v", state.TaskState)
+	}
+}
+
+func TestDAGCheckpointAccumulatesStateAcrossNodes(t *testing.T) {
+	ckpt := NewMemoryCheckpointer()
+	ctx := context.Background()
+
+	a := &statefulTask{id: "a", counter: 1}
+	b := &statefulTask{id: "b", counter: 2}
+	failing := NewFuncTask(func(context.Context) error { return fmt.Errorf("boom") })
+
+	dag := DAGTask(
+		WithNodes(
+			DAGNode{ID: "a", Task: a},
+			DAGNode{ID: "b", Task: b, DependsOn: []string{"a"}},
+			DAGNode{ID: "c", Task: failing, DependsOn: []string{"b"}},
+		),
+		WithCheckpointer(ckpt),
+		WithWorkflowID("wf-3"),
+	)
+	if err := dag.Commit(ctx); err == nil {
+		t.Fatal("expected node c to fail")
+	}
+
+	state, err := ckpt.Load(ctx, "wf-3")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, ok := state.TaskState["a"]; !ok {
+		t.Fatalf("expected node a's state to survive node b's checkpoint Save, got %v", state.TaskState)
+	}
+	if _, ok := state.TaskState["b"]; !ok {
+		t.Fatalf("expected node b's state in the checkpoint, got %v", state.TaskState)
+	}
+}
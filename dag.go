@@ -0,0 +1,384 @@
+package workflow
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"go.uber.org/multierr"
+)
+
+// DAGNode is one vertex of a DAGTask: a Task plus the IDs of the nodes it
+// depends on. DependsOn entries that don't name another node in the same
+// DAGTask are ignored.
+type DAGNode struct {
+	ID        string
+	Task      Task
+	DependsOn []string
+}
+
+// ErrCycle is returned by DAGTask.Commit when the node graph is not a DAG.
+type ErrCycle struct {
+	Nodes []string
+}
+
+func (e *ErrCycle) Error() string {
+	nodes := append([]string(nil), e.Nodes...)
+	sort.Strings(nodes)
+	return fmt.Sprintf("workflow: cycle detected among nodes %v", nodes)
+}
+
+// WithNodes appends the nodes a DAGTask runs.
+func WithNodes(nodes ...DAGNode) Option {
+	return func(o *option) {
+		o.dagNodes = append(o.dagNodes, nodes...)
+	}
+}
+
+// WithConcurrency bounds how many DAGTask nodes run at once. Zero or
+// negative means unbounded (one goroutine per ready node).
+func WithConcurrency(n int) Option {
+	return func(o *option) {
+		o.concurrency = n
+	}
+}
+
+type dagNode struct {
+	DAGNode
+	dependents []string
+	// indegree is the number of DependsOn entries that actually resolve to
+	// another node in this DAGTask; entries naming an unknown ID are
+	// ignored, per DAGNode's doc comment.
+	indegree int
+}
+
+type dagTask struct {
+	id          string
+	name        string
+	nodes       map[string]*dagNode
+	concurrency int
+
+	mu       sync.Mutex
+	executed []string
+
+	checkpointer Checkpointer
+	workflowID   string
+	// skip holds node IDs a ResumeTask found already completed in a prior
+	// checkpoint; they're treated as executed without re-running.
+	skip map[string]bool
+	// taskState holds the per-node payload a ResumeTask found in the last
+	// checkpoint, fed back into each Stateful node's task before it runs.
+	taskState map[string][]byte
+
+	observer Observer
+	timeout  time.Duration
+	deadline time.Time
+}
+
+// DAGTask runs tasks as soon as their dependencies succeed, bounded by
+// WithConcurrency. Unlike PipelineTask (strictly sequential) or
+// ParallelTask (fully concurrent), it supports an arbitrary dependency
+// graph between its nodes.
+func DAGTask(opts ...Option) Task {
+	uid := uuid.NewV1()
+	uidStr := hex.EncodeToString(uid[:])
+	o := &option{
+		name: "dag-task-" + uidStr,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	nodes := make(map[string]*dagNode, len(o.dagNodes))
+	for _, n := range o.dagNodes {
+		n := n
+		nodes[n.ID] = &dagNode{DAGNode: n}
+	}
+	for id, n := range nodes {
+		for _, dep := range n.DependsOn {
+			if parent, ok := nodes[dep]; ok {
+				parent.dependents = append(parent.dependents, id)
+				n.indegree++
+			}
+		}
+	}
+
+	concurrency := o.concurrency
+	if concurrency <= 0 {
+		concurrency = len(nodes)
+	}
+	workflowID := o.workflowID
+	if workflowID == "" {
+		workflowID = uidStr
+	}
+	return &dagTask{
+		id:           uidStr,
+		name:         o.name,
+		nodes:        nodes,
+		concurrency:  concurrency,
+		checkpointer: o.checkpointer,
+		workflowID:   workflowID,
+		observer:     observerOf(o),
+		timeout:      o.timeout,
+		deadline:     o.deadline,
+	}
+}
+
+func (s *dagTask) ID() string {
+	return s.id
+}
+
+func (s *dagTask) Name() string {
+	return s.name
+}
+
+// checkCycle performs Kahn's algorithm to confirm s.nodes form a DAG,
+// returning ErrCycle listing the offending node IDs otherwise.
+func (s *dagTask) checkCycle() error {
+	indegree := make(map[string]int, len(s.nodes))
+	for id, n := range s.nodes {
+		indegree[id] = n.indegree
+	}
+	queue := make([]string, 0, len(s.nodes))
+	for id, deg := range indegree {
+		if deg == 0 {
+			queue = append(queue, id)
+		}
+	}
+	visited := 0
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, dep := range s.nodes[id].dependents {
+			indegree[dep]--
+			if indegree[dep] == 0 {
+				queue = append(queue, dep)
+			}
+		}
+	}
+	if visited == len(s.nodes) {
+		return nil
+	}
+	remaining := make([]string, 0, len(s.nodes)-visited)
+	for id, deg := range indegree {
+		if deg > 0 {
+			remaining = append(remaining, id)
+		}
+	}
+	return &ErrCycle{Nodes: remaining}
+}
+
+func (s *dagTask) Commit(ctx context.Context) error {
+	if err := s.checkCycle(); err != nil {
+		return err
+	}
+	if len(s.nodes) == 0 {
+		return nil
+	}
+
+	ctx, budgetCancel := withBudget(ctx, s.timeout, s.deadline)
+	defer budgetCancel()
+
+	newCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indegree := make(map[string]int, len(s.nodes))
+	for id, n := range s.nodes {
+		indegree[id] = n.indegree
+	}
+	remaining := len(s.nodes)
+
+	// Nodes a prior checkpoint already completed are treated as executed
+	// without re-running, and their dependents' indegree is satisfied.
+	if len(s.skip) > 0 {
+		s.mu.Lock()
+		already := make(map[string]bool, len(s.executed))
+		for _, id := range s.executed {
+			already[id] = true
+		}
+		for id := range s.skip {
+			if _, ok := s.nodes[id]; !ok || already[id] {
+				continue
+			}
+			s.executed = append(s.executed, id)
+		}
+		s.mu.Unlock()
+
+		for id := range s.skip {
+			node, ok := s.nodes[id]
+			if !ok {
+				continue
+			}
+			delete(indegree, id)
+			remaining--
+			for _, dep := range node.dependents {
+				indegree[dep]--
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+
+	sem := make(chan struct{}, s.concurrency)
+	ready := make(chan string, len(s.nodes))
+
+	if remaining == 0 {
+		close(ready)
+	}
+	for id, deg := range indegree {
+		if deg == 0 {
+			ready <- id
+		}
+	}
+
+	for id := range ready {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			node := s.nodes[id]
+			select {
+			case <-newCtx.Done():
+			default:
+				s.mu.Lock()
+				taskState := s.taskState
+				s.mu.Unlock()
+				restoreState(node.Task, taskState)
+				start := time.Now()
+				taskCtx := s.observer.OnStart(newCtx, node.Task)
+				if err := node.Task.Commit(taskCtx); err != nil {
+					if isDeadlineErr(err) {
+						err = ErrDeadlineExceeded
+					}
+					s.observer.OnError(taskCtx, node.Task, err)
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				} else {
+					s.observer.OnSuccess(taskCtx, node.Task, time.Since(start))
+					s.mu.Lock()
+					s.executed = append(s.executed, id)
+					completed := append([]string(nil), s.executed...)
+					s.taskState = mergeState(s.taskState, stateOf(node.Task))
+					taskState := s.taskState
+					s.mu.Unlock()
+					if s.checkpointer != nil {
+						state := WorkflowState{
+							WorkflowID:     s.workflowID,
+							CompletedNodes: completed,
+							TaskState:      taskState,
+						}
+						if saveErr := s.checkpointer.Save(newCtx, s.workflowID, state); saveErr != nil {
+							errOnce.Do(func() {
+								firstErr = saveErr
+								cancel()
+							})
+						}
+					}
+				}
+			}
+
+			mu.Lock()
+			for _, dep := range node.dependents {
+				indegree[dep]--
+				if indegree[dep] == 0 {
+					ready <- dep
+				}
+			}
+			remaining--
+			if remaining == 0 {
+				close(ready)
+			}
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	if firstErr == nil {
+		if doneErr := ctx.Err(); isDeadlineErr(doneErr) {
+			firstErr = ErrDeadlineExceeded
+		}
+	}
+	if firstErr == nil && s.checkpointer != nil {
+		_ = s.checkpointer.Delete(ctx, s.workflowID)
+	}
+	return firstErr
+}
+
+func (s *dagTask) Rollback(ctx context.Context) error {
+	s.mu.Lock()
+	executed := make(map[string]bool, len(s.executed))
+	for _, id := range s.executed {
+		executed[id] = true
+	}
+	s.mu.Unlock()
+
+	if len(executed) == 0 {
+		return nil
+	}
+
+	// pending[id] counts executed dependents of id not yet rolled back; a
+	// node can be rolled back once it reaches zero, which runs rollbacks in
+	// reverse topological order while letting independent branches run in
+	// parallel.
+	pending := make(map[string]int, len(executed))
+	for id := range executed {
+		count := 0
+		for _, dep := range s.nodes[id].dependents {
+			if executed[dep] {
+				count++
+			}
+		}
+		pending[id] = count
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var err error
+	remaining := len(executed)
+
+	ready := make(chan string, len(executed))
+	for id, count := range pending {
+		if count == 0 {
+			ready <- id
+		}
+	}
+
+	for id := range ready {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			rbErr := s.nodes[id].Task.Rollback(ctx)
+			s.observer.OnRollback(ctx, s.nodes[id].Task, rbErr)
+
+			mu.Lock()
+			err = multierr.Append(err, rbErr)
+			for _, dep := range s.nodes[id].DependsOn {
+				if !executed[dep] {
+					continue
+				}
+				pending[dep]--
+				if pending[dep] == 0 {
+					ready <- dep
+				}
+			}
+			remaining--
+			if remaining == 0 {
+				close(ready)
+			}
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return err
+}
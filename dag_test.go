@@ -0,0 +1,38 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDAGTaskIgnoresDanglingDependsOn(t *testing.T) {
+	var ran bool
+	node := DAGNode{
+		ID: "a",
+		Task: NewFuncTask(func(context.Context) error {
+			ran = true
+			return nil
+		}),
+		DependsOn: []string{"does-not-exist"},
+	}
+
+	task := DAGTask(WithNodes(node))
+	if err := task.Commit(context.Background()); err != nil {
+		t.Fatalf("a dangling DependsOn should be ignored, not reported as a cycle: %v", err)
+	}
+	if !ran {
+		t.Fatal("node with a dangling DependsOn should still run")
+	}
+}
+
+func TestDAGTaskCheckCycleRealCycle(t *testing.T) {
+	task := DAGTask(WithNodes(
+		DAGNode{ID: "a", Task: NewFuncTask(func(context.Context) error { return nil }), DependsOn: []string{"b"}},
+		DAGNode{ID: "b", Task: NewFuncTask(func(context.Context) error { return nil }), DependsOn: []string{"a"}},
+	))
+
+	err := task.Commit(context.Background())
+	if _, ok := err.(*ErrCycle); !ok {
+		t.Fatalf("expected ErrCycle for a genuine cycle, got %v", err)
+	}
+}
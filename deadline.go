@@ -0,0 +1,60 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrDeadlineExceeded is returned in place of the raw ctx.Err() when a
+// task's overall time budget — set with WithTimeout or WithDeadline on the
+// task itself, or inherited from an enclosing PipelineTask/ParallelTask/
+// DAGTask — runs out.
+var ErrDeadlineExceeded = errors.New("workflow: deadline exceeded")
+
+// WithTimeout bounds the total time a RetryTask, PipelineTask, ParallelTask
+// or DAGTask is allowed to run, including every retry attempt — not just a
+// single one.
+func WithTimeout(d time.Duration) Option {
+	return func(o *option) {
+		o.timeout = d
+	}
+}
+
+// WithDeadline is WithTimeout expressed as an absolute time.
+func WithDeadline(t time.Time) Option {
+	return func(o *option) {
+		o.deadline = t
+	}
+}
+
+// withBudget wraps ctx with the timeout/deadline an option carries, if any.
+// Callers must always call the returned cancel func.
+func withBudget(ctx context.Context, timeout time.Duration, deadline time.Time) (context.Context, context.CancelFunc) {
+	switch {
+	case timeout > 0:
+		return context.WithTimeout(ctx, timeout)
+	case !deadline.IsZero():
+		return context.WithDeadline(ctx, deadline)
+	default:
+		return ctx, func() {}
+	}
+}
+
+// budgetRemaining returns how long is left until timeout/deadline elapses.
+// Zero means no budget was configured.
+func budgetRemaining(timeout time.Duration, deadline time.Time) time.Duration {
+	switch {
+	case timeout > 0:
+		return timeout
+	case !deadline.IsZero():
+		return time.Until(deadline)
+	default:
+		return 0
+	}
+}
+
+// isDeadlineErr reports whether err is, or wraps, a context deadline error.
+func isDeadlineErr(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
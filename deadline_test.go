@@ -0,0 +1,39 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryTaskDeadlineExceededMidBackoff(t *testing.T) {
+	alwaysFailing := NewFuncTask(func(context.Context) error {
+		return errors.New("boom")
+	})
+
+	task := RetryTask(alwaysFailing,
+		WithAttempts(100),
+		WithInterval(50*time.Millisecond),
+		WithTimeout(120*time.Millisecond),
+	)
+
+	err := task.Commit(context.Background())
+	if !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("expected ErrDeadlineExceeded once the budget runs out mid-backoff, got %v", err)
+	}
+}
+
+func TestPipelineTaskDeadlineInheritedByChild(t *testing.T) {
+	blocked := NewFuncTask(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	pipeline := PipelineTask(WithTasks(blocked), WithTimeout(50*time.Millisecond))
+
+	err := pipeline.Commit(context.Background())
+	if !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("expected the pipeline's deadline to propagate to its child and surface as ErrDeadlineExceeded, got %v", err)
+	}
+}
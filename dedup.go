@@ -0,0 +1,214 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRetryTimeout is returned by Group.Do to a caller waiting on a
+// deduplicated call when, within the waiter's backoff window (about 5s),
+// that call neither completes nor is released by a canceled leader for the
+// waiter to take over. A leader that is still running when the window runs
+// out triggers it as well.
+var ErrRetryTimeout = errors.New("workflow: no waiter took over leadership in time")
+
+// call is the state shared by every Group.Do call for a given key, for the
+// lifetime of that one dedup cycle: it's created when the first caller
+// arrives and removed from Group.calls as soon as it completes, so a later,
+// unrelated Do for the same key starts fresh. rollbackOnce is scoped to
+// this same lifetime, ensuring every caller that shared this particular
+// call rolls it back at most once, without leaking a permanent per-key
+// entry the way a Group-level map would.
+type call struct {
+	mu      sync.Mutex
+	done    bool
+	err     error
+	doneCh  chan struct{}
+	leading int32 // atomic: 1 while some caller is actively running fn
+
+	rollbackOnce sync.Once
+	rollbackErr  error
+}
+
+// Group collapses concurrent calls sharing the same key into a single
+// execution, broadcasting its result to every caller. It's a
+// cancellation-aware singleflight: if the current leader's context is
+// canceled while other callers are still waiting, one of them takes over
+// and re-runs fn with its own context instead of everyone failing.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+func NewGroup() *Group {
+	return &Group{
+		calls: make(map[string]*call),
+	}
+}
+
+// defaultGroup is shared by every DedupTask that isn't given an explicit
+// Group via WithGroup, so tasks built independently still dedup by key.
+var defaultGroup = NewGroup()
+
+// Do runs fn for key, or waits for whoever is already running it. If the
+// caller currently running fn has its context canceled while Do still has
+// other callers waiting on the same key, leadership transfers to one of
+// them, which retries fn with its own context. A waiter stops waiting with
+// ErrRetryTimeout after about 5s, whether or not the leader is still running.
+func (g *Group) Do(ctx context.Context, key string, fn func(context.Context) error) error {
+	_, err := g.doCall(ctx, key, fn)
+	return err
+}
+
+// doCall is Do, but also returns the call instance the caller ended up
+// sharing, so DedupTask can later scope its Rollback to that same instance.
+func (g *Group) doCall(ctx context.Context, key string, fn func(context.Context) error) (*call, error) {
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if !ok {
+		c = &call{doneCh: make(chan struct{})}
+		g.calls[key] = c
+	}
+	g.mu.Unlock()
+
+	err := g.run(ctx, key, c, fn)
+	return c, err
+}
+
+func (g *Group) run(ctx context.Context, key string, c *call, fn func(context.Context) error) error {
+	policy := &ExponentialPolicy{
+		Initial:        10 * time.Millisecond,
+		Multiplier:     2,
+		Max:            2 * time.Second,
+		MaxElapsedTime: 5 * time.Second,
+	}
+	var attempt int
+	for {
+		if atomic.CompareAndSwapInt32(&c.leading, 0, 1) {
+			return g.lead(ctx, key, c, fn)
+		}
+
+		select {
+		case <-c.doneCh:
+			c.mu.Lock()
+			err := c.err
+			c.mu.Unlock()
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		backOff, ok := policy.NextBackOff(attempt, nil)
+		if !ok {
+			return ErrRetryTimeout
+		}
+		attempt++
+		timer := time.NewTimer(backOff)
+		select {
+		case <-c.doneCh:
+			timer.Stop()
+			c.mu.Lock()
+			err := c.err
+			c.mu.Unlock()
+			return err
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// lead actually runs fn as the leader of key's call.
+func (g *Group) lead(ctx context.Context, key string, c *call, fn func(context.Context) error) error {
+	err := fn(ctx)
+	if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		// The leader's own context was canceled rather than fn genuinely
+		// failing; relinquish leadership so a waiting caller can retry with
+		// its own context instead of broadcasting a spurious failure.
+		atomic.StoreInt32(&c.leading, 0)
+		return err
+	}
+
+	c.mu.Lock()
+	c.done = true
+	c.err = err
+	c.mu.Unlock()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	close(c.doneCh)
+	return err
+}
+
+// WithGroup sets the Group a DedupTask dedups through. If unset, DedupTask
+// uses a package-level default Group shared by every caller.
+func WithGroup(g *Group) Option {
+	return func(o *option) {
+		o.group = g
+	}
+}
+
+type dedupTask struct {
+	key   string
+	task  Task
+	group *Group
+
+	mu   sync.Mutex
+	call *call
+}
+
+// DedupTask collapses concurrent Commit calls sharing key into a single
+// execution of t, broadcasting the result to every caller — useful when
+// the same idempotent task gets triggered by fan-in ParallelTask children.
+// Rollback likewise runs at most once among every DedupTask instance that
+// shared that same Commit cycle; a later, unrelated Commit/Rollback cycle
+// for the same key rolls back again.
+func DedupTask(key string, t Task, opts ...Option) Task {
+	o := &option{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	g := o.group
+	if g == nil {
+		g = defaultGroup
+	}
+	return &dedupTask{key: key, task: t, group: g}
+}
+
+func (d *dedupTask) ID() string {
+	return d.task.ID()
+}
+
+func (d *dedupTask) Name() string {
+	return d.task.Name()
+}
+
+func (d *dedupTask) Commit(ctx context.Context) error {
+	c, err := d.group.doCall(ctx, d.key, d.task.Commit)
+	d.mu.Lock()
+	d.call = c
+	d.mu.Unlock()
+	return err
+}
+
+func (d *dedupTask) Rollback(ctx context.Context) error {
+	d.mu.Lock()
+	c := d.call
+	d.mu.Unlock()
+	if c == nil {
+		// Rollback without a prior Commit on this instance: nothing was
+		// shared, so just run the wrapped task's Rollback directly.
+		return d.task.Rollback(ctx)
+	}
+	c.rollbackOnce.Do(func() {
+		c.rollbackErr = d.task.Rollback(ctx)
+	})
+	return c.rollbackErr
+}
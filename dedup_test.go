@@ -0,0 +1,127 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupRollbackRunsAgainOnLaterUnrelatedCycle(t *testing.T) {
+	g := NewGroup()
+
+	runCycle := func() int {
+		var rollbacks int
+		commit := NewFuncTask(func(context.Context) error { return nil })
+		rollback := NewFuncTask(func(context.Context) error {
+			rollbacks++
+			return nil
+		})
+
+		d := DedupTask("shared-key", funcPairTask{commit: commit, rollback: rollback}, WithGroup(g))
+
+		if err := d.Commit(context.Background()); err != nil {
+			t.Fatalf("Commit: %v", err)
+		}
+		if err := d.Rollback(context.Background()); err != nil {
+			t.Fatalf("Rollback: %v", err)
+		}
+		return rollbacks
+	}
+
+	if got := runCycle(); got != 1 {
+		t.Fatalf("first cycle: expected 1 rollback, got %d", got)
+	}
+	if got := runCycle(); got != 1 {
+		t.Fatalf("second, independent cycle on the same key: expected rollback to run again, got %d", got)
+	}
+}
+
+func TestGroupLeaderCancelHandsOffToWaiter(t *testing.T) {
+	g := NewGroup()
+	const key = "leader-handoff"
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	defer cancelLeader()
+	var calls int32
+	blockUntilDone := func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	leaderErr := make(chan error, 1)
+	go func() { leaderErr <- g.Do(leaderCtx, key, blockUntilDone) }()
+	time.Sleep(20 * time.Millisecond) // let the leader register and start running
+
+	waiterErr := make(chan error, 1)
+	go func() {
+		waiterErr <- g.Do(context.Background(), key, func(ctx context.Context) error {
+			atomic.AddInt32(&calls, 1)
+			return nil
+		})
+	}()
+	time.Sleep(20 * time.Millisecond) // let the waiter register
+
+	cancelLeader()
+
+	select {
+	case err := <-leaderErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected the leader's own canceled ctx error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("leader's Do never returned after its ctx was canceled")
+	}
+
+	select {
+	case err := <-waiterErr:
+		if err != nil {
+			t.Fatalf("expected the waiter that took over leadership to succeed, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no waiter took over leadership after the leader's ctx was canceled")
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("expected fn to run once per leader (original + handoff), got %d calls", got)
+	}
+}
+
+func TestGroupWaiterGetsRetryTimeoutWithNoHandoff(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits out the fixed 5s retry-timeout window")
+	}
+	g := NewGroup()
+	const key = "no-handoff"
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	defer cancelLeader()
+	blockForever := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	go func() { _ = g.Do(leaderCtx, key, blockForever) }()
+	time.Sleep(20 * time.Millisecond)
+
+	err := g.Do(context.Background(), key, blockForever)
+	if !errors.Is(err, ErrRetryTimeout) {
+		t.Fatalf("expected ErrRetryTimeout once the waiter's own backoff budget runs out with no handoff, got %v", err)
+	}
+}
+
+// funcPairTask lets a test drive distinct Commit/Rollback behavior without
+// a full Task implementation.
+type funcPairTask struct {
+	commit, rollback Task
+}
+
+func (f funcPairTask) ID() string   { return "funcPairTask" }
+func (f funcPairTask) Name() string { return "funcPairTask" }
+func (f funcPairTask) Commit(ctx context.Context) error {
+	return f.commit.Commit(ctx)
+}
+func (f funcPairTask) Rollback(ctx context.Context) error {
+	return f.rollback.Commit(ctx)
+}
@@ -0,0 +1,66 @@
+package workflow
+
+import (
+	"context"
+	"time"
+)
+
+// Observer is a cross-cutting hook into task lifecycle events, used by
+// every wrapper in this package to report span-like start/end events,
+// retries, and rollbacks. OnStart returns the context to use for the rest
+// of that task's execution (and anything it runs in turn), which is how an
+// Observer backed by a tracer nests child spans under their parent.
+type Observer interface {
+	OnStart(ctx context.Context, task Task) context.Context
+	OnSuccess(ctx context.Context, task Task, duration time.Duration)
+	OnError(ctx context.Context, task Task, err error)
+	OnRetry(ctx context.Context, task Task, attempt int, backOff time.Duration)
+	OnRollback(ctx context.Context, task Task, err error)
+}
+
+type noopObserver struct{}
+
+func (noopObserver) OnStart(ctx context.Context, _ Task) context.Context { return ctx }
+func (noopObserver) OnSuccess(context.Context, Task, time.Duration)      {}
+func (noopObserver) OnError(context.Context, Task, error)                {}
+func (noopObserver) OnRetry(context.Context, Task, int, time.Duration)   {}
+func (noopObserver) OnRollback(context.Context, Task, error)             {}
+
+// defaultObserver is used by every task constructor that isn't given
+// WithObserver, so existing callers see no behavior change.
+var defaultObserver Observer = noopObserver{}
+
+// WithObserver attaches an Observer to RetryTask, SafeTask, ParallelTask,
+// PipelineTask or DAGTask. DedupTask accepts it but reports no events.
+func WithObserver(o Observer) Option {
+	return func(opt *option) {
+		opt.observer = o
+	}
+}
+
+// observerOf returns o.observer, or defaultObserver if none was set.
+func observerOf(o *option) Observer {
+	if o.observer == nil {
+		return defaultObserver
+	}
+	return o.observer
+}
+
+// suppressedObserverKey marks a ctx so a wrapper like recoverTask, when
+// nested inside something that repeats Commit across an ambient span (only
+// RetryTask does today), skips its own OnError reporting instead of ending
+// that span early on a non-terminal attempt.
+type suppressedObserverKey struct{}
+
+// withSuppressedObserver marks ctx so nested wrappers don't report terminal
+// Observer events (OnSuccess/OnError) through it; the caller owning the
+// ambient span is responsible for the single terminal report instead.
+func withSuppressedObserver(ctx context.Context) context.Context {
+	return context.WithValue(ctx, suppressedObserverKey{}, true)
+}
+
+// observerSuppressed reports whether ctx was marked by withSuppressedObserver.
+func observerSuppressed(ctx context.Context) bool {
+	v, _ := ctx.Value(suppressedObserverKey{}).(bool)
+	return v
+}
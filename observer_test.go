@@ -0,0 +1,62 @@
+package workflow
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingObserver counts how many times each Observer method fires, to
+// check that a single logical task execution produces exactly one terminal
+// event (OnSuccess or OnError) per OnStart, no matter how many attempts or
+// nested wrappers ran underneath it.
+type countingObserver struct {
+	starts, successes, errors int32
+}
+
+func (c *countingObserver) OnStart(ctx context.Context, _ Task) context.Context {
+	atomic.AddInt32(&c.starts, 1)
+	return ctx
+}
+func (c *countingObserver) OnSuccess(context.Context, Task, time.Duration) {
+	atomic.AddInt32(&c.successes, 1)
+}
+func (c *countingObserver) OnError(context.Context, Task, error) {
+	atomic.AddInt32(&c.errors, 1)
+}
+func (c *countingObserver) OnRetry(context.Context, Task, int, time.Duration) {}
+func (c *countingObserver) OnRollback(context.Context, Task, error)           {}
+
+func TestRetryTaskWithSafeTaskReportsOneTerminalEvent(t *testing.T) {
+	obs := &countingObserver{}
+
+	var attempts int
+	panicky := NewFuncTask(func(context.Context) error {
+		attempts++
+		if attempts < 3 {
+			panic("boom")
+		}
+		return nil
+	})
+
+	task := RetryTask(SafeTask(panicky, WithObserver(obs)),
+		WithAttempts(5),
+		WithInterval(time.Millisecond),
+		WithObserver(obs),
+	)
+
+	if err := task.Commit(context.Background()); err != nil {
+		t.Fatalf("expected the third attempt to succeed, got %v", err)
+	}
+
+	if got := atomic.LoadInt32(&obs.starts); got != 1 {
+		t.Fatalf("expected exactly 1 OnStart for the whole retry loop, got %d", got)
+	}
+	if got := atomic.LoadInt32(&obs.successes); got != 1 {
+		t.Fatalf("expected exactly 1 terminal OnSuccess, got %d", got)
+	}
+	if got := atomic.LoadInt32(&obs.errors); got != 0 {
+		t.Fatalf("expected the 2 recovered panics to not each end the shared span via OnError, got %d", got)
+	}
+}
@@ -0,0 +1,84 @@
+package workflow
+
+import (
+	"context"
+	"time"
+)
+
+// Task is the minimal unit of work that every wrapper in this package
+// composes: something that can be committed, and undone if a later step
+// in the same workflow fails.
+type Task interface {
+	ID() string
+	Name() string
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// Policy controls what a retryTask does once its wrapped Task returns an
+// error: retry it, or give up and let the caller roll back.
+type Policy uint8
+
+// Option configures the task constructors in this package.
+type Option func(*option)
+
+type option struct {
+	name  string
+	tasks []Task
+
+	attempt  int
+	interval time.Duration
+	policy   Policy
+
+	retryPolicy    RetryPolicy
+	retryableFunc  func(error) bool
+	maxElapsedTime time.Duration
+
+	dagNodes    []DAGNode
+	concurrency int
+
+	checkpointer Checkpointer
+	workflowID   string
+
+	group *Group
+
+	observer Observer
+
+	timeout  time.Duration
+	deadline time.Time
+}
+
+// WithName sets the task's Name().
+func WithName(name string) Option {
+	return func(o *option) {
+		o.name = name
+	}
+}
+
+// WithTasks appends the child tasks a ParallelTask or PipelineTask runs.
+func WithTasks(tasks ...Task) Option {
+	return func(o *option) {
+		o.tasks = append(o.tasks, tasks...)
+	}
+}
+
+// WithAttempts sets the number of retry attempts for RetryTask.
+func WithAttempts(attempts int) Option {
+	return func(o *option) {
+		o.attempt = attempts
+	}
+}
+
+// WithInterval sets the base retry interval for RetryTask.
+func WithInterval(interval time.Duration) Option {
+	return func(o *option) {
+		o.interval = interval
+	}
+}
+
+// WithPolicy sets the Policy (retry or revert) a RetryTask applies on error.
+func WithPolicy(policy Policy) Option {
+	return func(o *option) {
+		o.policy = policy
+	}
+}
@@ -0,0 +1,95 @@
+package workflow
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OTelObserver reports task lifecycle events as OpenTelemetry spans,
+// counters, and histograms. Spans nest correctly because OnStart stores the
+// child span in the context it returns, and every wrapper in this package
+// passes that context on to whatever it runs next.
+type OTelObserver struct {
+	tracer trace.Tracer
+
+	duration  metric.Float64Histogram
+	retries   metric.Int64Counter
+	rollbacks metric.Int64Counter
+	inflight  metric.Int64UpDownCounter
+}
+
+// NewOTelObserver builds an OTelObserver using the global TracerProvider and
+// the given MeterProvider, both named tracerName.
+func NewOTelObserver(tracerName string, mp metric.MeterProvider) (*OTelObserver, error) {
+	meter := mp.Meter(tracerName)
+
+	duration, err := meter.Float64Histogram("task.duration", metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	retries, err := meter.Int64Counter("task.retries")
+	if err != nil {
+		return nil, err
+	}
+	rollbacks, err := meter.Int64Counter("task.rollback.count")
+	if err != nil {
+		return nil, err
+	}
+	inflight, err := meter.Int64UpDownCounter("task.parallel.inflight")
+	if err != nil {
+		return nil, err
+	}
+	return &OTelObserver{
+		tracer:    otel.Tracer(tracerName),
+		duration:  duration,
+		retries:   retries,
+		rollbacks: rollbacks,
+		inflight:  inflight,
+	}, nil
+}
+
+func (o *OTelObserver) OnStart(ctx context.Context, task Task) context.Context {
+	ctx, span := o.tracer.Start(ctx, task.Name())
+	span.SetAttributes(attribute.String("task.id", task.ID()))
+	o.inflight.Add(ctx, 1)
+	return ctx
+}
+
+func (o *OTelObserver) OnSuccess(ctx context.Context, task Task, duration time.Duration) {
+	o.duration.Record(ctx, duration.Seconds(), metric.WithAttributes(attribute.String("task.name", task.Name())))
+	o.inflight.Add(ctx, -1)
+	span := trace.SpanFromContext(ctx)
+	span.SetStatus(codes.Ok, "")
+	span.End()
+}
+
+func (o *OTelObserver) OnError(ctx context.Context, task Task, err error) {
+	o.duration.Record(ctx, 0, metric.WithAttributes(attribute.String("task.name", task.Name())))
+	o.inflight.Add(ctx, -1)
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.End()
+}
+
+func (o *OTelObserver) OnRetry(ctx context.Context, task Task, attempt int, backOff time.Duration) {
+	o.retries.Add(ctx, 1, metric.WithAttributes(attribute.String("task.name", task.Name())))
+	trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+		attribute.Int("attempt", attempt),
+		attribute.String("back_off", backOff.String()),
+	))
+}
+
+func (o *OTelObserver) OnRollback(ctx context.Context, task Task, err error) {
+	attrs := []attribute.KeyValue{attribute.String("task.name", task.Name())}
+	if err != nil {
+		attrs = append(attrs, attribute.Bool("error", true))
+	}
+	o.rollbacks.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
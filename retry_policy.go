@@ -0,0 +1,227 @@
+package workflow
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RetryPolicy decides, for a retryTask, how long to wait before the next
+// attempt and whether an error should be retried at all. Errors for which
+// IsRetryable returns false are returned immediately and do not consume the
+// retry budget tracked by NextBackOff.
+type RetryPolicy interface {
+	// NextBackOff returns the delay before the next attempt and whether a
+	// retry should happen at all. attempt is zero-based: it counts retries
+	// already performed, not the initial Commit.
+	NextBackOff(attempt int, err error) (time.Duration, bool)
+	// IsRetryable reports whether err should be retried.
+	IsRetryable(err error) bool
+}
+
+// ExponentialPolicy grows the backoff interval geometrically between
+// Initial and Max, and stops once MaxAttempts or MaxElapsedTime is
+// exceeded. A zero MaxAttempts or MaxElapsedTime means "no limit".
+type ExponentialPolicy struct {
+	Initial        time.Duration
+	Multiplier     float64
+	Max            time.Duration
+	MaxAttempts    int
+	MaxElapsedTime time.Duration
+	// Retryable optionally excludes errors (e.g. context cancellation,
+	// validation errors) from the retry budget. A nil func retries everything.
+	Retryable func(error) bool
+
+	startOnce sync.Once
+	startedAt time.Time
+}
+
+func (p *ExponentialPolicy) NextBackOff(attempt int, _ error) (time.Duration, bool) {
+	p.startOnce.Do(func() {
+		p.startedAt = time.Now()
+	})
+	if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+		return 0, false
+	}
+	if p.MaxElapsedTime > 0 && time.Since(p.startedAt) >= p.MaxElapsedTime {
+		return 0, false
+	}
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	d := float64(p.Initial) * math.Pow(multiplier, float64(attempt))
+	if p.Max > 0 && d > float64(p.Max) {
+		d = float64(p.Max)
+	}
+	return time.Duration(d), true
+}
+
+func (p *ExponentialPolicy) IsRetryable(err error) bool {
+	if p.Retryable == nil {
+		return true
+	}
+	return p.Retryable(err)
+}
+
+// ConstantPolicy retries at a fixed interval until MaxAttempts is reached.
+// A zero MaxAttempts means "no limit".
+type ConstantPolicy struct {
+	Interval    time.Duration
+	MaxAttempts int
+	Retryable   func(error) bool
+}
+
+func (p *ConstantPolicy) NextBackOff(attempt int, _ error) (time.Duration, bool) {
+	if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+		return 0, false
+	}
+	return p.Interval, true
+}
+
+func (p *ConstantPolicy) IsRetryable(err error) bool {
+	if p.Retryable == nil {
+		return true
+	}
+	return p.Retryable(err)
+}
+
+// JitterMode selects how JitteredPolicy spreads out the backoff its Base
+// policy computes, to avoid many callers retrying in lockstep.
+type JitterMode uint8
+
+const (
+	// JitterFull picks a random duration in [0, base).
+	JitterFull JitterMode = iota
+	// JitterEqual picks a random duration in [base/2, base).
+	JitterEqual
+	// JitterDecorrelated picks sleep = min(Cap, random(base, prev*3)), where
+	// prev is the delay returned by the previous call.
+	JitterDecorrelated
+)
+
+// JitteredPolicy wraps a base RetryPolicy and jitters the delay it returns,
+// which matters when many ParallelTask children retry at the same time and
+// would otherwise all wake up together.
+type JitteredPolicy struct {
+	Base RetryPolicy
+	Mode JitterMode
+	// Cap bounds the delay JitterDecorrelated can grow to. If zero, the
+	// upper bound of the current attempt's random range (prev*3) is used
+	// instead, i.e. the random pick is left uncapped.
+	Cap time.Duration
+
+	mu   sync.Mutex
+	prev time.Duration
+}
+
+func (p *JitteredPolicy) NextBackOff(attempt int, err error) (time.Duration, bool) {
+	base, ok := p.Base.NextBackOff(attempt, err)
+	if !ok {
+		return 0, false
+	}
+	if base <= 0 {
+		return base, true
+	}
+	switch p.Mode {
+	case JitterEqual:
+		return base/2 + randDuration(base/2), true
+	case JitterDecorrelated:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		prev := p.prev
+		if prev <= 0 {
+			prev = base
+		}
+		next := base + randDuration(prev*3-base)
+		cap := p.Cap
+		if cap <= 0 {
+			cap = prev * 3
+		}
+		if next > cap {
+			next = cap
+		}
+		p.prev = next
+		return next, true
+	default: // JitterFull
+		return randDuration(base), true
+	}
+}
+
+func (p *JitteredPolicy) IsRetryable(err error) bool {
+	return p.Base.IsRetryable(err)
+}
+
+// randDuration returns a random duration in [0, d). Non-positive d returns 0.
+func randDuration(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// retryableOverride lets WithRetryableFunc replace IsRetryable on whatever
+// RetryPolicy is in effect, built-in or user-supplied.
+type retryableOverride struct {
+	RetryPolicy
+	fn func(error) bool
+}
+
+func (r *retryableOverride) IsRetryable(err error) bool {
+	return r.fn(err)
+}
+
+// WithRetryPolicy sets the RetryPolicy a RetryTask uses instead of the
+// built-in one derived from WithAttempts/WithInterval.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(o *option) {
+		o.retryPolicy = p
+	}
+}
+
+// WithRetryableFunc overrides IsRetryable on whatever RetryPolicy is in
+// effect, so callers can exclude errors like context cancellation or
+// validation failures from the retry budget.
+func WithRetryableFunc(fn func(error) bool) Option {
+	return func(o *option) {
+		o.retryableFunc = fn
+	}
+}
+
+// WithMaxElapsedTime bounds the total time the built-in ExponentialPolicy
+// spends retrying, derived from WithAttempts/WithInterval. It has no effect
+// when WithRetryPolicy supplies a policy explicitly.
+func WithMaxElapsedTime(d time.Duration) Option {
+	return func(o *option) {
+		o.maxElapsedTime = d
+	}
+}
+
+// noRetryPolicy never retries, preserving the original retryTask behavior
+// for attempts <= 0 ("run once"): unlike ConstantPolicy, where a zero
+// MaxAttempts means unlimited, here zero really means zero.
+type noRetryPolicy struct{}
+
+func (noRetryPolicy) NextBackOff(int, error) (time.Duration, bool) { return 0, false }
+
+func (noRetryPolicy) IsRetryable(error) bool { return false }
+
+// defaultRetryPolicy builds the ExponentialPolicy that backs the legacy
+// attempts/interval options, preserving retryTask's original backoff curve:
+// the multiplier is chosen so that, over "attempts" retries, the interval
+// grows by at most 2x.
+func defaultRetryPolicy(attempts int, interval, maxElapsedTime time.Duration) RetryPolicy {
+	if attempts <= 0 {
+		return noRetryPolicy{}
+	}
+	if attempts < 2 || interval <= 0 {
+		return &ConstantPolicy{Interval: 0, MaxAttempts: attempts}
+	}
+	return &ExponentialPolicy{
+		Initial:        interval,
+		Multiplier:     math.Pow(2, 1/float64(attempts-1)),
+		MaxAttempts:    attempts,
+		MaxElapsedTime: maxElapsedTime,
+	}
+}
@@ -0,0 +1,55 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDefaultRetryPolicyNoAttempts(t *testing.T) {
+	for _, attempts := range []int{0, -1} {
+		rp := defaultRetryPolicy(attempts, time.Millisecond, 0)
+		if rp.IsRetryable(errors.New("boom")) {
+			t.Fatalf("attempts=%d: IsRetryable should be false so RetryTask never loops", attempts)
+		}
+		if _, ok := rp.NextBackOff(0, errors.New("boom")); ok {
+			t.Fatalf("attempts=%d: NextBackOff should refuse to retry", attempts)
+		}
+	}
+}
+
+func TestRetryTaskNoOptionsCommitsOnce(t *testing.T) {
+	var calls int
+	failing := NewFuncTask(func(context.Context) error {
+		calls++
+		return errors.New("boom")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if err := RetryTask(failing).Commit(ctx); err == nil {
+		t.Fatal("expected the wrapped task's error to propagate")
+	}
+	if calls != 1 {
+		t.Fatalf("RetryTask(t) with no options should commit once, got %d calls", calls)
+	}
+}
+
+func TestJitteredPolicyDecorrelatedDefaultCapRandomizes(t *testing.T) {
+	base := &ConstantPolicy{Interval: 100 * time.Millisecond}
+	p := &JitteredPolicy{Base: base, Mode: JitterDecorrelated}
+
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 50; i++ {
+		d, ok := p.NextBackOff(0, nil)
+		if !ok {
+			t.Fatal("ConstantPolicy should never refuse to retry")
+		}
+		seen[d] = true
+	}
+	if len(seen) == 1 {
+		t.Fatal("JitterDecorrelated with a zero Cap should not collapse every delay to the same value")
+	}
+}
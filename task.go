@@ -5,13 +5,11 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
-	"math"
 	"reflect"
 	"runtime"
 	"sync"
 	"time"
 
-	"github.com/cenkalti/backoff/v4"
 	uuid "github.com/satori/go.uuid"
 	"go.uber.org/multierr"
 )
@@ -42,11 +40,17 @@ func (f FuncTask) Rollback(context.Context) error {
 
 type recoverTask struct {
 	Task
+	observer Observer
 }
 
-func SafeTask(t Task) Task {
+func SafeTask(t Task, opts ...Option) Task {
+	o := &option{}
+	for _, opt := range opts {
+		opt(o)
+	}
 	return &recoverTask{
-		Task: t,
+		Task:     t,
+		observer: observerOf(o),
 	}
 }
 
@@ -56,7 +60,11 @@ func (rt *recoverTask) Commit(ctx context.Context) (err error) {
 			const size = 64 << 10
 			buf := make([]byte, size)
 			buf = buf[:runtime.Stack(buf, false)]
-			err = multierr.Append(err, fmt.Errorf("[Recover] found:%v,trace:\n%s", r, buf))
+			recoverErr := fmt.Errorf("[Recover] found:%v,trace:\n%s", r, buf)
+			err = multierr.Append(err, recoverErr)
+			if !observerSuppressed(ctx) {
+				rt.observer.OnError(ctx, rt.Task, recoverErr)
+			}
 		}
 	}()
 	err = rt.Task.Commit(ctx)
@@ -69,7 +77,9 @@ func (rt *recoverTask) Rollback(ctx context.Context) (err error) {
 			const size = 64 << 10
 			buf := make([]byte, size)
 			buf = buf[:runtime.Stack(buf, false)]
-			err = multierr.Append(err, fmt.Errorf("[Recover] found:%v,trace:\n%s", r, buf))
+			recoverErr := fmt.Errorf("[Recover] found:%v,trace:\n%s", r, buf)
+			err = multierr.Append(err, recoverErr)
+			rt.observer.OnError(ctx, rt.Task, recoverErr)
 		}
 	}()
 	err = rt.Task.Rollback(ctx)
@@ -83,9 +93,13 @@ const (
 
 type retryTask struct {
 	Task
-	attempts int
-	interval time.Duration
-	policy   Policy
+	attempts    int
+	interval    time.Duration
+	policy      Policy
+	retryPolicy RetryPolicy
+	observer    Observer
+	timeout     time.Duration
+	deadline    time.Time
 }
 
 func RetryTask(t Task, opts ...Option) Task {
@@ -95,71 +109,96 @@ func RetryTask(t Task, opts ...Option) Task {
 	for _, opt := range opts {
 		opt(o)
 	}
+	maxElapsedTime := o.maxElapsedTime
+	if maxElapsedTime == 0 {
+		maxElapsedTime = budgetRemaining(o.timeout, o.deadline)
+	}
+	rp := o.retryPolicy
+	if rp == nil {
+		rp = defaultRetryPolicy(o.attempt, o.interval, maxElapsedTime)
+	}
+	if o.retryableFunc != nil {
+		rp = &retryableOverride{RetryPolicy: rp, fn: o.retryableFunc}
+	}
 	return &retryTask{
-		Task:     t,
-		attempts: o.attempt,
-		interval: o.interval,
-		policy:   o.policy,
+		Task:        t,
+		attempts:    o.attempt,
+		interval:    o.interval,
+		policy:      o.policy,
+		retryPolicy: rp,
+		observer:    observerOf(o),
+		timeout:     o.timeout,
+		deadline:    o.deadline,
 	}
 }
 
 func (rt *retryTask) Commit(ctx context.Context) error {
-	err := rt.Task.Commit(ctx)
+	ctx, cancel := withBudget(ctx, rt.timeout, rt.deadline)
+	defer cancel()
+
+	start := time.Now()
+	ctx = rt.observer.OnStart(ctx, rt.Task)
+	attemptCtx := withSuppressedObserver(ctx)
+
+	err := rt.Task.Commit(attemptCtx)
 	if err == nil {
+		rt.observer.OnSuccess(ctx, rt.Task, time.Since(start))
 		return nil
 	}
-	if rt.policy == PolicyRetry {
-		var tempAttempts int
-		backOff := rt.newBackOff() // 退避算法 保证时间间隔为指数级增长
-		currentInterval := 0 * time.Millisecond
-		timer := time.NewTimer(currentInterval)
-		for {
-			select {
-			case <-timer.C:
-				shouldRetry := tempAttempts < rt.attempts
-				if !shouldRetry {
-					timer.Stop()
-					return err
-				}
-				if retryErr := rt.Task.Commit(ctx); retryErr == nil {
-					shouldRetry = false
-				} else {
-					err = multierr.Append(err, fmt.Errorf("%d try,%w", tempAttempts+1, retryErr))
-				}
-				if !shouldRetry {
-					timer.Stop()
-					return err
-				}
-				// 计算下一次
-				currentInterval = backOff.NextBackOff()
-				tempAttempts++
-				// 定时器重置
-				timer.Reset(currentInterval)
-			case <-ctx.Done():
+	if isDeadlineErr(err) {
+		err = ErrDeadlineExceeded
+	}
+	if rt.policy != PolicyRetry || !rt.retryPolicy.IsRetryable(err) {
+		rt.observer.OnError(ctx, rt.Task, err)
+		return err
+	}
+	var attempt int
+	backOff, ok := rt.retryPolicy.NextBackOff(attempt, err)
+	if !ok {
+		rt.observer.OnError(ctx, rt.Task, err)
+		return err
+	}
+	rt.observer.OnRetry(ctx, rt.Task, attempt+1, backOff)
+	timer := time.NewTimer(backOff)
+	for {
+		select {
+		case <-timer.C:
+			retryErr := rt.Task.Commit(attemptCtx)
+			if retryErr == nil {
+				timer.Stop()
+				rt.observer.OnSuccess(ctx, rt.Task, time.Since(start))
+				return nil
+			}
+			if isDeadlineErr(retryErr) {
+				retryErr = ErrDeadlineExceeded
+			}
+			err = multierr.Append(err, fmt.Errorf("%d try,%w", attempt+1, retryErr))
+			if !rt.retryPolicy.IsRetryable(retryErr) {
 				timer.Stop()
-				return ctx.Err()
+				rt.observer.OnError(ctx, rt.Task, err)
+				return err
 			}
+			attempt++
+			// 计算下一次
+			backOff, ok = rt.retryPolicy.NextBackOff(attempt, retryErr)
+			if !ok {
+				timer.Stop()
+				rt.observer.OnError(ctx, rt.Task, err)
+				return err
+			}
+			rt.observer.OnRetry(ctx, rt.Task, attempt+1, backOff)
+			// 定时器重置
+			timer.Reset(backOff)
+		case <-ctx.Done():
+			timer.Stop()
+			doneErr := ctx.Err()
+			if isDeadlineErr(doneErr) {
+				doneErr = ErrDeadlineExceeded
+			}
+			rt.observer.OnError(ctx, rt.Task, doneErr)
+			return doneErr
 		}
 	}
-	return err
-}
-
-func (rt *retryTask) newBackOff() backoff.BackOff {
-	if rt.attempts < 2 || rt.interval <= 0 {
-		return &backoff.ZeroBackOff{}
-	}
-
-	b := backoff.NewExponentialBackOff()
-	b.InitialInterval = rt.interval
-
-	// calculate the multiplier for the given number of attempts
-	// so that applying the multiplier for the given number of attempts will not exceed 2 times the initial interval
-	// it allows to control the progression along the attempts
-	b.Multiplier = math.Pow(2, 1/float64(rt.attempts-1))
-
-	// according to docs, b.Reset() must be called before using
-	b.Reset()
-	return b
 }
 
 type parallelTask struct {
@@ -173,6 +212,10 @@ type parallelTask struct {
 
 	errOnce sync.Once
 	err     error
+
+	observer Observer
+	timeout  time.Duration
+	deadline time.Time
 }
 
 func ParallelTask(opts ...Option) Task {
@@ -186,9 +229,12 @@ func ParallelTask(opts ...Option) Task {
 		opt(o)
 	}
 	return &parallelTask{
-		id:    uidStr,
-		name:  o.name,
-		tasks: o.tasks,
+		id:       uidStr,
+		name:     o.name,
+		tasks:    o.tasks,
+		observer: observerOf(o),
+		timeout:  o.timeout,
+		deadline: o.deadline,
 	}
 }
 
@@ -201,6 +247,9 @@ func (s *parallelTask) Name() string {
 }
 
 func (s *parallelTask) Commit(ctx context.Context) error {
+	ctx, budgetCancel := withBudget(ctx, s.timeout, s.deadline)
+	defer budgetCancel()
+
 	newCtx, cancel := context.WithCancel(ctx)
 	var wg sync.WaitGroup
 	for _, task := range s.tasks {
@@ -209,11 +258,19 @@ func (s *parallelTask) Commit(ctx context.Context) error {
 			select {
 			case <-ctx.Done():
 			default:
-				if err := t.Commit(ctx); err != nil {
+				start := time.Now()
+				taskCtx := s.observer.OnStart(ctx, t)
+				if err := t.Commit(taskCtx); err != nil {
+					if isDeadlineErr(err) {
+						err = ErrDeadlineExceeded
+					}
+					s.observer.OnError(taskCtx, t, err)
 					s.errOnce.Do(func() {
 						s.err = err
 						cancel()
 					})
+				} else {
+					s.observer.OnSuccess(taskCtx, t, time.Since(start))
 				}
 				s.mutex.Lock()
 				s.executedTasks = append(s.executedTasks, t)
@@ -224,6 +281,11 @@ func (s *parallelTask) Commit(ctx context.Context) error {
 	}
 	wg.Wait()
 	cancel()
+	if s.err == nil {
+		if doneErr := ctx.Err(); isDeadlineErr(doneErr) {
+			s.err = ErrDeadlineExceeded
+		}
+	}
 	return s.err
 }
 
@@ -240,6 +302,7 @@ func (s *parallelTask) Rollback(ctx context.Context) error {
 			default:
 				err = t.Rollback(ctx)
 			}
+			s.observer.OnRollback(ctx, t, err)
 			s.mutex.Lock()
 			s.err = multierr.Append(s.err, err)
 			s.mutex.Unlock()
@@ -256,6 +319,20 @@ type pipelineTask struct {
 
 	tasks []Task
 	cur   int
+
+	checkpointer Checkpointer
+	workflowID   string
+	resumeFrom   int
+	// taskState holds the per-task payload a ResumeTask found in the last
+	// checkpoint (fed back into each Stateful task as Commit reaches it,
+	// including the steps it skips), and
+	// accumulates an entry per completed task as Commit checkpoints, so a
+	// later Save never drops an earlier task's state.
+	taskState map[string][]byte
+
+	observer Observer
+	timeout  time.Duration
+	deadline time.Time
 }
 
 func PipelineTask(opts ...Option) Task {
@@ -268,10 +345,19 @@ func PipelineTask(opts ...Option) Task {
 	for _, opt := range opts {
 		opt(o)
 	}
+	workflowID := o.workflowID
+	if workflowID == "" {
+		workflowID = uidStr
+	}
 	return &pipelineTask{
-		id:    uidStr,
-		name:  o.name,
-		tasks: o.tasks,
+		id:           uidStr,
+		name:         o.name,
+		tasks:        o.tasks,
+		checkpointer: o.checkpointer,
+		workflowID:   workflowID,
+		observer:     observerOf(o),
+		timeout:      o.timeout,
+		deadline:     o.deadline,
 	}
 }
 
@@ -284,11 +370,40 @@ func (s *pipelineTask) Name() string {
 }
 
 func (s *pipelineTask) Commit(ctx context.Context) error {
+	ctx, cancel := withBudget(ctx, s.timeout, s.deadline)
+	defer cancel()
+
 	for index, task := range s.tasks {
-		if err := task.Commit(ctx); err != nil {
+		restoreState(task, s.taskState)
+		if index < s.resumeFrom {
+			continue
+		}
+		start := time.Now()
+		taskCtx := s.observer.OnStart(ctx, task)
+		if err := task.Commit(taskCtx); err != nil {
+			if isDeadlineErr(err) {
+				err = ErrDeadlineExceeded
+			}
+			s.observer.OnError(taskCtx, task, err)
 			s.cur = index
 			return err
 		}
+		s.observer.OnSuccess(taskCtx, task, time.Since(start))
+		if s.checkpointer != nil {
+			s.taskState = mergeState(s.taskState, stateOf(task))
+			state := WorkflowState{
+				WorkflowID: s.workflowID,
+				Step:       index,
+				TaskState:  s.taskState,
+			}
+			if err := s.checkpointer.Save(ctx, s.workflowID, state); err != nil {
+				s.cur = index
+				return err
+			}
+		}
+	}
+	if s.checkpointer != nil {
+		_ = s.checkpointer.Delete(ctx, s.workflowID)
 	}
 	return nil
 }
@@ -296,7 +411,9 @@ func (s *pipelineTask) Commit(ctx context.Context) error {
 func (s *pipelineTask) Rollback(ctx context.Context) error {
 	var err error
 	for i := s.cur; i >= 0; i-- {
-		err = multierr.Append(err, s.tasks[i].Rollback(ctx))
+		rbErr := s.tasks[i].Rollback(ctx)
+		s.observer.OnRollback(ctx, s.tasks[i], rbErr)
+		err = multierr.Append(err, rbErr)
 	}
 	return err
 }